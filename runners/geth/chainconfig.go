@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// forkActivations lists EIP-155 mainnet hardforks in chronological order,
+// each paired with the mutation that activates it (at block/time zero) on a
+// freshly-allocated params.ChainConfig. buildChainConfig applies a prefix of
+// this list up to and including the requested fork, so e.g. "--fork berlin"
+// gets every fork through Berlin switched on and nothing past it.
+var forkActivations = []struct {
+	name  string
+	apply func(cfg *params.ChainConfig)
+}{
+	{"frontier", func(cfg *params.ChainConfig) {}},
+	{"homestead", func(cfg *params.ChainConfig) { cfg.HomesteadBlock = big.NewInt(0) }},
+	{"tangerinewhistle", func(cfg *params.ChainConfig) { cfg.EIP150Block = big.NewInt(0) }},
+	{"spuriousdragon", func(cfg *params.ChainConfig) { cfg.EIP155Block, cfg.EIP158Block = big.NewInt(0), big.NewInt(0) }},
+	{"byzantium", func(cfg *params.ChainConfig) { cfg.ByzantiumBlock = big.NewInt(0) }},
+	{"constantinople", func(cfg *params.ChainConfig) { cfg.ConstantinopleBlock = big.NewInt(0) }},
+	{"petersburg", func(cfg *params.ChainConfig) { cfg.PetersburgBlock = big.NewInt(0) }},
+	{"istanbul", func(cfg *params.ChainConfig) { cfg.IstanbulBlock = big.NewInt(0) }},
+	{"muirglacier", func(cfg *params.ChainConfig) { cfg.MuirGlacierBlock = big.NewInt(0) }},
+	{"berlin", func(cfg *params.ChainConfig) { cfg.BerlinBlock = big.NewInt(0) }},
+	{"london", func(cfg *params.ChainConfig) { cfg.LondonBlock = big.NewInt(0) }},
+	{"arrowglacier", func(cfg *params.ChainConfig) { cfg.ArrowGlacierBlock = big.NewInt(0) }},
+	{"grayglacier", func(cfg *params.ChainConfig) { cfg.GrayGlacierBlock = big.NewInt(0) }},
+	{"paris", func(cfg *params.ChainConfig) {
+		cfg.MergeNetsplitBlock = big.NewInt(0)
+		cfg.TerminalTotalDifficulty = big.NewInt(0)
+	}},
+	{"shanghai", func(cfg *params.ChainConfig) { cfg.ShanghaiTime = uint64Ptr(0) }},
+	{"cancun", func(cfg *params.ChainConfig) { cfg.CancunTime = uint64Ptr(0) }},
+	{"prague", func(cfg *params.ChainConfig) { cfg.PragueTime = uint64Ptr(0) }},
+}
+
+func uint64Ptr(v uint64) *uint64 { return &v }
+
+// buildChainConfig resolves --fork/--chain-config into a params.ChainConfig.
+// chainConfigPath, if set, takes precedence and is read as a genesis-style
+// JSON chain config; otherwise fork is looked up in forkActivations.
+func buildChainConfig(fork, chainConfigPath string) (*params.ChainConfig, error) {
+	if chainConfigPath != "" {
+		data, err := os.ReadFile(chainConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading chain config: %w", err)
+		}
+		cfg := new(params.ChainConfig)
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing chain config: %w", err)
+		}
+		return cfg, nil
+	}
+
+	cfg := &params.ChainConfig{ChainID: big.NewInt(1)}
+	target := strings.ToLower(fork)
+	if target == "" {
+		target = "prague"
+	}
+
+	found := false
+	for _, activation := range forkActivations {
+		activation.apply(cfg)
+		if activation.name == target {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown fork %q (want one of frontier..prague)", fork)
+	}
+	return cfg, nil
+}
+
+// parseExtraEIPs parses the --eips flag, a comma-separated list of EIP
+// numbers, into the []int form vm.Config.ExtraEips expects.
+func parseExtraEIPs(value string) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var eips []int
+	for _, raw := range strings.Split(value, ",") {
+		eip, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid EIP number %q: %w", raw, err)
+		}
+		eips = append(eips, eip)
+	}
+	return eips, nil
+}
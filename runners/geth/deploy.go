@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// deployResult captures what the runner reports about running a contract's
+// constructor (gas used and duration), plus the deployed address that the
+// timed calls that follow are made against. The runtime code itself is not
+// copied here; it stays in state at Address.
+type deployResult struct {
+	Address  common.Address
+	GasUsed  uint64
+	Duration time.Duration
+}
+
+// deployContract runs initCode as a contract creation (CREATE, or CREATE2
+// when salt is non-nil) from callerAddress, timing the constructor's
+// execution the same way the runner times calls. The returned address holds
+// whatever runtime bytecode the constructor chose to return.
+func deployContract(evm *vm.EVM, callerAddress common.Address, initCode []byte, salt *big.Int, gasLimit uint64) (deployResult, error) {
+	start := time.Now()
+
+	value := uint256.NewInt(0)
+	var (
+		address  common.Address
+		leftOver uint64
+		err      error
+	)
+	if salt != nil {
+		_, address, leftOver, err = evm.Create2(vm.AccountRef(callerAddress), initCode, gasLimit, value, uint256.MustFromBig(salt))
+	} else {
+		_, address, leftOver, err = evm.Create(vm.AccountRef(callerAddress), initCode, gasLimit, value)
+	}
+
+	duration := time.Since(start)
+	if err != nil {
+		return deployResult{}, fmt.Errorf("deploying contract: %w", err)
+	}
+
+	return deployResult{
+		Address:  address,
+		GasUsed:  gasLimit - leftOver,
+		Duration: duration,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/holiman/uint256"
+)
+
+// loadPrestate reads a core.GenesisAlloc-compatible JSON file (address ->
+// {balance, nonce, code, storage}), the same shape go-ethereum's state tests
+// use to hydrate fixtures in tests/state_test_util.go.
+func loadPrestate(path string) (core.GenesisAlloc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading prestate: %w", err)
+	}
+	alloc := make(core.GenesisAlloc)
+	if err := json.Unmarshal(data, &alloc); err != nil {
+		return nil, fmt.Errorf("parsing prestate: %w", err)
+	}
+	return alloc, nil
+}
+
+// applyPrestate writes a GenesisAlloc into statedb, account by account.
+func applyPrestate(statedb *state.StateDB, alloc core.GenesisAlloc) {
+	for address, account := range alloc {
+		statedb.SetBalance(address, uint256.MustFromBig(account.Balance), tracing.BalanceChangeUnspecified)
+		statedb.SetNonce(address, account.Nonce)
+		if len(account.Code) > 0 {
+			statedb.SetCode(address, account.Code)
+		}
+		for key, value := range account.Storage {
+			statedb.SetState(address, key, value)
+		}
+	}
+}
+
+// dumpPoststate reads the given addresses back out of statedb into a
+// GenesisAlloc for --poststate-dump, so a run's effects can be compared
+// against the prestate or fed into another fixture.
+func dumpPoststate(statedb *state.StateDB, addresses []common.Address) core.GenesisAlloc {
+	alloc := make(core.GenesisAlloc, len(addresses))
+	for _, address := range addresses {
+		if _, seen := alloc[address]; seen {
+			continue
+		}
+
+		account := core.GenesisAccount{
+			Balance: statedb.GetBalance(address).ToBig(),
+			Nonce:   statedb.GetNonce(address),
+			Code:    statedb.GetCode(address),
+		}
+
+		storage := make(map[common.Hash]common.Hash)
+		statedb.ForEachStorage(address, func(key, value common.Hash) bool {
+			storage[key] = value
+			return true
+		})
+		if len(storage) > 0 {
+			account.Storage = storage
+		}
+
+		alloc[address] = account
+	}
+	return alloc
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"os"
@@ -13,13 +14,42 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
 	"github.com/spf13/cobra"
 )
 
 var (
-	contractCode string
-	calldata     string
-	numRuns      int
+	contractCode    string
+	calldata        string
+	numRuns         int
+	authorizations  []string
+	traceMode       string
+	deployMode      bool
+	saltHex         string
+	constructorArgs string
+	fork            string
+	chainConfigPath string
+	eips            string
+	blockNumber     uint64
+	timestampFlag   uint64
+	baseFeeHex      string
+	blobBaseFeeHex  string
+	coinbaseHex     string
+	difficultyHex   string
+	randomHex       string
+	prestatePath    string
+	poststateDump   string
+	txType          string
+	accessListPath  string
+	gasPriceHex     string
+	gasFeeCapHex    string
+	gasTipCapHex    string
+	blobHashesFlag  string
+	blobFeeCapHex   string
+	prewarm         bool
+	reportFormat    string
+	warmupRuns      int
+	minDurationFlag string
 )
 
 var cmd = &cobra.Command{
@@ -29,20 +59,44 @@ var cmd = &cobra.Command{
 		contractCodeBytes := common.FromHex(contractCode)
 		calldataBytes := common.FromHex(calldata)
 
-		zeroAddress := common.BytesToAddress(common.FromHex("0x0000000000000000000000000000000000000000"))
 		callerAddress := common.BytesToAddress(common.FromHex("0x1000000000000000000000000000000000000001"))
 		contractAddress := common.BytesToAddress(common.FromHex("0x2000000000000000000000000000000000000002"))
 
-		config := params.MainnetChainConfig
+		config, err := buildChainConfig(fork, chainConfigPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		extraEips, err := parseExtraEIPs(eips)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
 		defaultGenesis := core.DefaultGenesisBlock()
-		genesis := &core.Genesis{
-			Config:     config,
+
+		header := &types.Header{
 			Coinbase:   defaultGenesis.Coinbase,
 			Difficulty: defaultGenesis.Difficulty,
 			GasLimit:   defaultGenesis.GasLimit,
-			Number:     config.LondonBlock.Uint64(),
-			Timestamp:  *config.ShanghaiTime,
-			Alloc:      defaultGenesis.Alloc,
+			Number:     new(big.Int).SetUint64(blockNumber),
+			Time:       timestampFlag,
+		}
+		if coinbaseHex != "" {
+			header.Coinbase = common.HexToAddress(coinbaseHex)
+		}
+		if baseFeeHex != "" {
+			header.BaseFee = new(big.Int).SetBytes(common.FromHex(baseFeeHex))
+		} else if config.IsLondon(header.Number) {
+			header.BaseFee = params.InitialBaseFee
+		}
+		if randomHex != "" {
+			header.Difficulty = big.NewInt(0)
+			header.MixDigest = common.HexToHash(randomHex)
+		} else if difficultyHex != "" {
+			header.Difficulty = new(big.Int).SetBytes(common.FromHex(difficultyHex))
+		} else if config.TerminalTotalDifficulty != nil && config.TerminalTotalDifficulty.Sign() == 0 {
+			header.Difficulty = big.NewInt(0)
 		}
 
 		statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
@@ -50,44 +104,196 @@ var cmd = &cobra.Command{
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
-		statedb.SetCode(contractAddress, contractCodeBytes)
-		statedb.AddAddressToAccessList(contractAddress)
+		if !deployMode {
+			statedb.SetCode(contractAddress, contractCodeBytes)
+		}
+
+		var prestate core.GenesisAlloc
+		if prestatePath != "" {
+			prestate, err = loadPrestate(prestatePath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			applyPrestate(statedb, prestate)
+		}
+
+		authSpecs, err := parseAuthorizationFlags(authorizations)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for _, spec := range authSpecs {
+			auth, err := signAuthorization(spec)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if err := applyAuthorization(statedb, auth); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
 
 		zeroValue := big.NewInt(0)
+		zeroValueU256 := uint256.NewInt(0)
 		gasLimit := ^uint64(0)
 
+		hexOrZero := func(hex string) *big.Int {
+			if hex == "" {
+				return zeroValue
+			}
+			return new(big.Int).SetBytes(common.FromHex(hex))
+		}
+
+		var accessList types.AccessList
+		if accessListPath != "" {
+			accessList, err = loadAccessList(accessListPath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
 		msg := core.Message{
 			To:                &contractAddress,
 			From:              callerAddress,
 			Nonce:             0,
 			Value:             zeroValue,
 			GasLimit:          gasLimit,
-			GasPrice:          zeroValue,
-			GasFeeCap:         zeroValue,
-			GasTipCap:         zeroValue,
+			GasPrice:          hexOrZero(gasPriceHex),
+			GasFeeCap:         hexOrZero(gasFeeCapHex),
+			GasTipCap:         hexOrZero(gasTipCapHex),
 			Data:              calldataBytes,
-			AccessList:        types.AccessList{},
-			BlobGasFeeCap:     zeroValue,
-			BlobHashes:        []common.Hash{},
+			AccessList:        accessList,
+			BlobGasFeeCap:     hexOrZero(blobFeeCapHex),
+			BlobHashes:        parseBlobHashes(blobHashesFlag),
 			SkipAccountChecks: false,
 		}
 
-		blockContext := core.NewEVMBlockContext(genesis.ToBlock().Header(), nil, &zeroAddress)
+		if err := shapeMessageForTxType(txType, &msg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		tracerHooks, printTraceSummary, err := buildTracer(traceMode, os.Stderr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		blockContext := core.NewEVMBlockContext(header, nil, &header.Coinbase)
+		if blobBaseFeeHex != "" {
+			blockContext.BlobBaseFee = new(big.Int).SetBytes(common.FromHex(blobBaseFeeHex))
+		} else if config.IsCancun(header.Number, header.Time) {
+			blockContext.BlobBaseFee = big.NewInt(params.BlobTxMinBlobGasprice)
+		}
 		txContext := core.NewEVMTxContext(&msg)
 
-		for i := 0; i < numRuns; i++ {
-			evm := vm.NewEVM(blockContext, txContext, statedb.Copy(), config, vm.Config{})
+		if deployMode {
+			initCode := append(append([]byte{}, contractCodeBytes...), common.FromHex(constructorArgs)...)
+
+			var salt *big.Int
+			if saltHex != "" {
+				salt = new(big.Int).SetBytes(common.FromHex(saltHex))
+			}
+
+			deployEVM := vm.NewEVM(blockContext, statedb, config, vm.Config{Tracer: tracerHooks, ExtraEips: extraEips})
+			deployEVM.SetTxContext(txContext)
+			result, err := deployContract(deployEVM, callerAddress, initCode, salt, gasLimit)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("deploy gas=%d time=%d\n", result.GasUsed, result.Duration.Microseconds())
+			contractAddress = result.Address
+			msg.To = &contractAddress
+		}
+
+		if prewarm && len(msg.AccessList) == 0 {
+			collector := newAccessListCollector()
+			dryEVM := vm.NewEVM(blockContext, statedb.Copy(), config, vm.Config{Tracer: collector.Hooks()})
+			dryEVM.SetTxContext(txContext)
+			if _, _, err := dryEVM.Call(vm.AccountRef(callerAddress), contractAddress, calldataBytes, gasLimit, zeroValueU256); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			msg.AccessList = collector.AccessList()
+		}
+
+		rules := config.Rules(header.Number, header.Difficulty.Sign() == 0, header.Time)
+		statedb.PrepareAccessList(callerAddress, &contractAddress, vm.ActivePrecompiles(rules), msg.AccessList)
+
+		var minDuration time.Duration
+		if minDurationFlag != "" {
+			minDuration, err = time.ParseDuration(minDurationFlag)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
+		for i := 0; i < warmupRuns; i++ {
+			evm := vm.NewEVM(blockContext, statedb.Copy(), config, vm.Config{ExtraEips: extraEips})
+			evm.SetTxContext(txContext)
+			if _, _, err := evm.Call(vm.AccountRef(callerAddress), contractAddress, calldataBytes, gasLimit, zeroValueU256); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
+		var (
+			lastRunState *state.StateDB
+			durationsNs  []int64
+			gasUsedAll   []uint64
+			elapsedTotal time.Duration
+		)
+		for i := 0; i < numRuns || (minDuration > 0 && elapsedTotal < minDuration); i++ {
+			runState := statedb.Copy()
+			evm := vm.NewEVM(blockContext, runState, config, vm.Config{Tracer: tracerHooks, ExtraEips: extraEips})
+			evm.SetTxContext(txContext)
 
 			start := time.Now()
-			_, _, err := evm.Call(vm.AccountRef(callerAddress), contractAddress, calldataBytes, gasLimit, zeroValue)
-			timeTaken := time.Since(start)
+			_, leftOverGas, err := evm.Call(vm.AccountRef(callerAddress), contractAddress, calldataBytes, gasLimit, zeroValueU256)
+			elapsed := time.Since(start)
 
 			if err != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 
-			fmt.Println(timeTaken.Microseconds())
+			durationsNs = append(durationsNs, elapsed.Nanoseconds())
+			gasUsedAll = append(gasUsedAll, gasLimit-leftOverGas)
+			elapsedTotal += elapsed
+			lastRunState = runState
+		}
+
+		if err := writeReport(os.Stdout, reportFormat, durationsNs, gasUsedAll); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		if printTraceSummary != nil {
+			printTraceSummary()
+		}
+
+		if poststateDump != "" && lastRunState != nil {
+			addresses := []common.Address{callerAddress, contractAddress}
+			for address := range prestate {
+				addresses = append(addresses, address)
+			}
+
+			alloc := dumpPoststate(lastRunState, addresses)
+			data, err := json.MarshalIndent(alloc, "", "  ")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if err := os.WriteFile(poststateDump, data, 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
 		}
 	},
 }
@@ -99,6 +305,34 @@ func init() {
 	cmd.MarkFlagRequired("calldata")
 	cmd.Flags().IntVar(&numRuns, "num-runs", 0, "Number of times to run the benchmark")
 	cmd.MarkFlagRequired("num-runs")
+	cmd.Flags().StringArrayVar(&authorizations, "authorizations", nil, "EIP-7702 authorization tuple (chainId,address,nonce,signer_privkey) or a path to a JSON file containing an array of them; may be repeated")
+	cmd.Flags().StringVar(&traceMode, "trace", "none", "EVM tracer to attach: none, struct, json, or opcount")
+	cmd.Flags().BoolVar(&deployMode, "deploy", false, "Treat contract-code as init code and run it as a contract creation before timing calls")
+	cmd.Flags().StringVar(&saltHex, "salt", "", "Hex salt for CREATE2 deployment; if unset, --deploy uses CREATE")
+	cmd.Flags().StringVar(&constructorArgs, "constructor-args", "", "Hex-encoded constructor arguments appended to the init code in --deploy mode")
+	cmd.Flags().StringVar(&fork, "fork", "prague", "Hardfork to activate, frontier..prague")
+	cmd.Flags().StringVar(&chainConfigPath, "chain-config", "", "Path to a JSON params.ChainConfig; overrides --fork")
+	cmd.Flags().StringVar(&eips, "eips", "", "Comma-separated EIP numbers to enable via vm.Config.ExtraEips")
+	cmd.Flags().Uint64Var(&blockNumber, "block-number", 0, "Block number for the call's BlockContext")
+	cmd.Flags().Uint64Var(&timestampFlag, "timestamp", 0, "Block timestamp for the call's BlockContext")
+	cmd.Flags().StringVar(&baseFeeHex, "base-fee", "", "Hex base fee for the call's BlockContext")
+	cmd.Flags().StringVar(&blobBaseFeeHex, "blob-base-fee", "", "Hex blob base fee for the call's BlockContext")
+	cmd.Flags().StringVar(&coinbaseHex, "coinbase", "", "Hex coinbase address for the call's BlockContext")
+	cmd.Flags().StringVar(&difficultyHex, "difficulty", "", "Hex block difficulty; ignored if --random is set")
+	cmd.Flags().StringVar(&randomHex, "random", "", "Hex prevrandao; setting this also zeroes difficulty to signal a post-merge block")
+	cmd.Flags().StringVar(&prestatePath, "prestate", "", "Path to a core.GenesisAlloc-compatible JSON to apply to state before each run")
+	cmd.Flags().StringVar(&poststateDump, "poststate-dump", "", "Path to write a core.GenesisAlloc-compatible JSON of the final run's resulting state")
+	cmd.Flags().StringVar(&txType, "tx-type", "legacy", "Transaction envelope to model: legacy, access, dynamic, or blob")
+	cmd.Flags().StringVar(&accessListPath, "access-list", "", "Path to a JSON types.AccessList to warm before the timed runs")
+	cmd.Flags().StringVar(&gasPriceHex, "gas-price", "", "Hex gas price for a legacy/access-list transaction")
+	cmd.Flags().StringVar(&gasFeeCapHex, "gas-fee-cap", "", "Hex max fee per gas for a dynamic-fee or blob transaction")
+	cmd.Flags().StringVar(&gasTipCapHex, "gas-tip-cap", "", "Hex max priority fee per gas for a dynamic-fee or blob transaction")
+	cmd.Flags().StringVar(&blobHashesFlag, "blob-hashes", "", "Comma-separated hex versioned blob hashes for a blob transaction")
+	cmd.Flags().StringVar(&blobFeeCapHex, "blob-fee-cap", "", "Hex max fee per blob gas for a blob transaction")
+	cmd.Flags().BoolVar(&prewarm, "prewarm", false, "Auto-derive an access list by dry-running the call once before the timed runs")
+	cmd.Flags().StringVar(&reportFormat, "report", "human", "Report format for timing results: human, json, or csv")
+	cmd.Flags().IntVar(&warmupRuns, "warmup", 0, "Number of untimed runs to discard before timing begins")
+	cmd.Flags().StringVar(&minDurationFlag, "min-duration", "", "Keep running past --num-runs until this much measured time has elapsed (e.g. \"200ms\")")
 }
 
 func main() {
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// reportSummary is the aggregate view over a set of timed runs that --report
+// computes so external tooling doesn't have to.
+type reportSummary struct {
+	Runs     int     `json:"runs"`
+	MeanNs   float64 `json:"meanNs"`
+	MedianNs float64 `json:"medianNs"`
+	StddevNs float64 `json:"stddevNs"`
+	MinNs    int64   `json:"minNs"`
+	MaxNs    int64   `json:"maxNs"`
+	P95Ns    int64   `json:"p95Ns"`
+	P99Ns    int64   `json:"p99Ns"`
+	MeanGas  float64 `json:"meanGas"`
+}
+
+func summarize(durationsNs []int64, gasUsed []uint64) reportSummary {
+	sorted := append([]int64{}, durationsNs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	n := len(sorted)
+
+	var gasSum uint64
+	for _, g := range gasUsed {
+		gasSum += g
+	}
+	var meanGas float64
+	if len(gasUsed) > 0 {
+		meanGas = float64(gasSum) / float64(len(gasUsed))
+	}
+
+	if n == 0 {
+		return reportSummary{MeanGas: meanGas}
+	}
+
+	var sum int64
+	for _, d := range sorted {
+		sum += d
+	}
+	mean := float64(sum) / float64(n)
+
+	var variance float64
+	for _, d := range sorted {
+		diff := float64(d) - mean
+		variance += diff * diff
+	}
+	variance /= float64(n)
+
+	return reportSummary{
+		Runs:     n,
+		MeanNs:   mean,
+		MedianNs: percentile(sorted, 50),
+		StddevNs: math.Sqrt(variance),
+		MinNs:    sorted[0],
+		MaxNs:    sorted[n-1],
+		P95Ns:    int64(percentile(sorted, 95)),
+		P99Ns:    int64(percentile(sorted, 99)),
+		MeanGas:  meanGas,
+	}
+}
+
+// percentile linearly interpolates the p-th percentile (0-100) out of an
+// already-sorted slice.
+func percentile(sorted []int64, p float64) float64 {
+	if len(sorted) == 1 {
+		return float64(sorted[0])
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return float64(sorted[lower])
+	}
+	frac := rank - float64(lower)
+	return float64(sorted[lower])*(1-frac) + float64(sorted[upper])*frac
+}
+
+// writeReport renders per-run nanosecond timings plus the aggregate summary
+// in the requested --report format: "human" (default, one line per run then
+// a summary line), "json" (a single summary object with the raw samples), or
+// "csv" (one row per run plus a trailing summary row).
+func writeReport(w io.Writer, format string, durationsNs []int64, gasUsed []uint64) error {
+	summary := summarize(durationsNs, gasUsed)
+
+	switch format {
+	case "", "human":
+		for _, d := range durationsNs {
+			fmt.Fprintln(w, d)
+		}
+		if summary.Runs == 0 {
+			return nil
+		}
+		fmt.Fprintf(w, "runs=%d mean=%.0fns median=%.0fns stddev=%.0fns min=%dns max=%dns p95=%dns p99=%dns meanGas=%.0f\n",
+			summary.Runs, summary.MeanNs, summary.MedianNs, summary.StddevNs, summary.MinNs, summary.MaxNs, summary.P95Ns, summary.P99Ns, summary.MeanGas)
+		return nil
+	case "json":
+		return json.NewEncoder(w).Encode(struct {
+			Summary reportSummary `json:"summary"`
+			RunsNs  []int64       `json:"runsNs"`
+		}{summary, durationsNs})
+	case "csv":
+		fmt.Fprintln(w, "run,ns")
+		for i, d := range durationsNs {
+			fmt.Fprintf(w, "%d,%d\n", i, d)
+		}
+		if summary.Runs == 0 {
+			return nil
+		}
+		fmt.Fprintf(w, "summary,mean=%.0f,median=%.0f,stddev=%.0f,min=%d,max=%d,p95=%d,p99=%d,meanGas=%.0f\n",
+			summary.MeanNs, summary.MedianNs, summary.StddevNs, summary.MinNs, summary.MaxNs, summary.P95Ns, summary.P99Ns, summary.MeanGas)
+		return nil
+	default:
+		return fmt.Errorf("unknown report format %q (want human, json, or csv)", format)
+	}
+}
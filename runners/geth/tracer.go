@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// buildTracer resolves the --trace flag into a *tracing.Hooks, writing its
+// output to w, plus a summary func to run once after all timed runs (nil
+// unless mode needs one, e.g. "opcount"). An empty/"none" mode returns a nil
+// Hooks, matching vm.Config.Tracer's default.
+func buildTracer(mode string, w io.Writer) (*tracing.Hooks, func(), error) {
+	switch mode {
+	case "", "none":
+		return nil, nil, nil
+	case "struct":
+		return newStructLogger(w).Hooks(), nil, nil
+	case "json":
+		return newJSONLogger(w).Hooks(), nil, nil
+	case "opcount":
+		l := newOpcountLogger(w)
+		return l.Hooks(), l.PrintSummary, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown trace mode %q (want none, struct, json, or opcount)", mode)
+	}
+}
+
+// structLogger prints one line per opcode in the same pc/op/gas/stack shape
+// as go-ethereum's standard struct logger.
+type structLogger struct {
+	out io.Writer
+}
+
+func newStructLogger(out io.Writer) *structLogger {
+	return &structLogger{out: out}
+}
+
+func (l *structLogger) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnOpcode: l.onOpcode,
+		OnFault:  l.onFault,
+	}
+}
+
+func (l *structLogger) onFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	l.onOpcode(pc, op, gas, cost, scope, nil, depth, err)
+}
+
+func (l *structLogger) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	stackData := scope.StackData()
+	stack := make([]string, len(stackData))
+	for i, v := range stackData {
+		stack[i] = v.Hex()
+	}
+	fmt.Fprintf(l.out, "pc=%-5d op=%-14s gas=%-10d cost=%-6d depth=%-2d stack=%v\n", pc, vm.OpCode(op).String(), gas, cost, depth, stack)
+}
+
+// jsonLogger streams one JSON object per opcode to its writer, mirroring
+// cmd/evm's --json tracer output.
+type jsonLogger struct {
+	encoder *json.Encoder
+}
+
+type jsonLogEntry struct {
+	Pc      uint64   `json:"pc"`
+	Op      string   `json:"op"`
+	Gas     uint64   `json:"gas"`
+	GasCost uint64   `json:"gasCost"`
+	Depth   int      `json:"depth"`
+	Stack   []string `json:"stack"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func newJSONLogger(out io.Writer) *jsonLogger {
+	return &jsonLogger{encoder: json.NewEncoder(out)}
+}
+
+func (l *jsonLogger) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnOpcode: l.onOpcode,
+		OnFault:  l.onFault,
+	}
+}
+
+func (l *jsonLogger) onFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	l.writeEntry(pc, op, gas, cost, depth, scope, err)
+}
+
+func (l *jsonLogger) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	l.writeEntry(pc, op, gas, cost, depth, scope, err)
+}
+
+func (l *jsonLogger) writeEntry(pc uint64, op byte, gas, cost uint64, depth int, scope tracing.OpContext, err error) {
+	entry := jsonLogEntry{
+		Pc:      pc,
+		Op:      vm.OpCode(op).String(),
+		Gas:     gas,
+		GasCost: cost,
+		Depth:   depth,
+	}
+	if scope != nil {
+		stackData := scope.StackData()
+		stack := make([]string, len(stackData))
+		for i, v := range stackData {
+			stack[i] = v.Hex()
+		}
+		entry.Stack = stack
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if encErr := l.encoder.Encode(entry); encErr != nil {
+		log.Warn("failed to encode trace entry", "err", encErr)
+	}
+}
+
+// opcountLogger accumulates a histogram of opcodes and gas-by-opcode across
+// every execution its hooks are attached to (in the runner: the --deploy
+// constructor and all timed runs), and prints one combined summary when
+// PrintSummary is called. No separate per-run histogram is kept or printed.
+type opcountLogger struct {
+	out     io.Writer
+	counts  map[vm.OpCode]uint64
+	gasUsed map[vm.OpCode]uint64
+}
+
+func newOpcountLogger(out io.Writer) *opcountLogger {
+	return &opcountLogger{
+		out:     out,
+		counts:  make(map[vm.OpCode]uint64),
+		gasUsed: make(map[vm.OpCode]uint64),
+	}
+}
+
+func (l *opcountLogger) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnOpcode: l.onOpcode,
+		OnFault:  l.onFault,
+	}
+}
+
+// PrintSummary writes the accumulated opcode/gas histogram, sorted by gas
+// spent descending. The runner calls this once after all timed runs.
+func (l *opcountLogger) PrintSummary() {
+	type row struct {
+		op    vm.OpCode
+		count uint64
+		gas   uint64
+	}
+	rows := make([]row, 0, len(l.counts))
+	for op, count := range l.counts {
+		rows = append(rows, row{op, count, l.gasUsed[op]})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].gas > rows[j].gas })
+
+	fmt.Fprintln(l.out, "opcode histogram:")
+	for _, r := range rows {
+		fmt.Fprintf(l.out, "  %-14s count=%-8d gas=%d\n", r.op.String(), r.count, r.gas)
+	}
+}
+
+func (l *opcountLogger) onFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	l.record(op, cost)
+}
+
+func (l *opcountLogger) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	l.record(op, cost)
+}
+
+func (l *opcountLogger) record(op byte, cost uint64) {
+	l.counts[vm.OpCode(op)]++
+	l.gasUsed[vm.OpCode(op)] += cost
+}
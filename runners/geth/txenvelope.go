@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// shapeMessageForTxType validates --tx-type and zeroes or rejects the fields
+// of msg that don't belong to the selected envelope, so e.g. --tx-type
+// legacy --blob-hashes doesn't silently build a blob-shaped message.
+func shapeMessageForTxType(txType string, msg *core.Message) error {
+	switch txType {
+	case "legacy":
+		if msg.AccessList != nil {
+			return fmt.Errorf("--tx-type legacy does not support --access-list (use access, dynamic, or blob)")
+		}
+		msg.GasFeeCap, msg.GasTipCap = msg.GasPrice, msg.GasPrice
+		msg.BlobGasFeeCap, msg.BlobHashes = nil, nil
+	case "access":
+		msg.GasFeeCap, msg.GasTipCap = msg.GasPrice, msg.GasPrice
+		msg.BlobGasFeeCap, msg.BlobHashes = nil, nil
+	case "dynamic":
+		if msg.BlobHashes != nil {
+			return fmt.Errorf("--tx-type dynamic does not support --blob-hashes (use blob)")
+		}
+		msg.GasPrice = msg.GasFeeCap
+		msg.BlobGasFeeCap = nil
+	case "blob":
+		if len(msg.BlobHashes) == 0 {
+			return fmt.Errorf("--tx-type blob requires --blob-hashes")
+		}
+		msg.GasPrice = msg.GasFeeCap
+	default:
+		return fmt.Errorf("unknown tx type %q (want legacy, access, dynamic, or blob)", txType)
+	}
+	return nil
+}
+
+// loadAccessList reads a JSON-encoded types.AccessList from path, the same
+// shape eth_createAccessList returns and go-ethereum transactions embed.
+func loadAccessList(path string) (types.AccessList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading access list: %w", err)
+	}
+	var accessList types.AccessList
+	if err := json.Unmarshal(data, &accessList); err != nil {
+		return nil, fmt.Errorf("parsing access list: %w", err)
+	}
+	return accessList, nil
+}
+
+// parseBlobHashes turns a comma-separated list of hex versioned hashes into
+// the []common.Hash form core.Message.BlobHashes expects.
+func parseBlobHashes(value string) []common.Hash {
+	if value == "" {
+		return nil
+	}
+	var hashes []common.Hash
+	for _, raw := range strings.Split(value, ",") {
+		hashes = append(hashes, common.HexToHash(strings.TrimSpace(raw)))
+	}
+	return hashes
+}
+
+// accessListCollector records, during a dry run via tracing.Hooks, the sender
+// and recipient of every call frame and every storage slot read or written by
+// SLOAD/SSTORE, so --prewarm can auto-derive an access list from the
+// calldata's actual execution path instead of requiring one hand-written.
+// Addresses reached only through BALANCE, EXTCODE* or SELFDESTRUCT are not
+// recorded.
+type accessListCollector struct {
+	addresses map[common.Address]struct{}
+	slots     map[common.Address]map[common.Hash]struct{}
+}
+
+func newAccessListCollector() *accessListCollector {
+	return &accessListCollector{
+		addresses: make(map[common.Address]struct{}),
+		slots:     make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+func (c *accessListCollector) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnEnter:  c.onEnter,
+		OnOpcode: c.onOpcode,
+	}
+}
+
+func (c *accessListCollector) onEnter(depth int, typ byte, from, to common.Address, input []byte, gas uint64, value *big.Int) {
+	c.addresses[from] = struct{}{}
+	c.addresses[to] = struct{}{}
+}
+
+func (c *accessListCollector) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if vm.OpCode(op) != vm.SLOAD && vm.OpCode(op) != vm.SSTORE {
+		return
+	}
+	stackData := scope.StackData()
+	if len(stackData) == 0 {
+		return
+	}
+	address := scope.Address()
+	slot := common.Hash(stackData[len(stackData)-1].Bytes32())
+
+	c.addresses[address] = struct{}{}
+	if c.slots[address] == nil {
+		c.slots[address] = make(map[common.Hash]struct{})
+	}
+	c.slots[address][slot] = struct{}{}
+}
+
+// AccessList renders the collected addresses and slots as a types.AccessList.
+func (c *accessListCollector) AccessList() types.AccessList {
+	accessList := make(types.AccessList, 0, len(c.addresses))
+	for address := range c.addresses {
+		tuple := types.AccessTuple{Address: address}
+		for slot := range c.slots[address] {
+			tuple.StorageKeys = append(tuple.StorageKeys, slot)
+		}
+		accessList = append(accessList, tuple)
+	}
+	return accessList
+}